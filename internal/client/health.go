@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// HealthMetrics is a snapshot of the load signals a Client publishes to its
+// balancer on each health tick.
+type HealthMetrics struct {
+	CPU    float64
+	Mem    float64
+	Conns  int64
+	QDepth int
+}
+
+// HealthReporter gathers the load signals sent in a HEALTH message. Users
+// can inject their own implementation via Client.healthReporter to report
+// metrics other than the host's /proc/loadavg and /proc/meminfo.
+type HealthReporter interface {
+	Report() (HealthMetrics, error)
+}
+
+// procHealthReporter is the default HealthReporter, reading CPU and memory
+// load from procfs and connection/queue depth from the owning Client.
+type procHealthReporter struct {
+	c *Client
+}
+
+func newProcHealthReporter(c *Client) *procHealthReporter {
+	return &procHealthReporter{c: c}
+}
+
+func (r *procHealthReporter) Report() (HealthMetrics, error) {
+	cpu, err := readLoadAvg()
+	if err != nil {
+		return HealthMetrics{}, err
+	}
+
+	mem, err := readMemFraction()
+	if err != nil {
+		return HealthMetrics{}, err
+	}
+
+	return HealthMetrics{
+		CPU: cpu,
+		Mem: mem,
+		// connCount is reset on every read so Conns reflects packets
+		// forwarded since the last report, not a lifetime total -
+		// otherwise it only ever grows and is useless for weighted
+		// or least-loaded selection.
+		Conns:  atomic.SwapInt64(&r.c.connCount, 0),
+		QDepth: len(r.c.packets),
+	}, nil
+}
+
+func readLoadAvg() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 1 {
+		return 0, fmt.Errorf("unexpected /proc/loadavg contents: %q", data)
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+func readMemFraction() (float64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var total, available float64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch strings.TrimSuffix(fields[0], ":") {
+		case "MemTotal":
+			total, _ = strconv.ParseFloat(fields[1], 64)
+		case "MemAvailable":
+			available, _ = strconv.ParseFloat(fields[1], 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("could not determine MemTotal from /proc/meminfo")
+	}
+	return (total - available) / total, nil
+}
+
+func formatHealthLine(m HealthMetrics) string {
+	return fmt.Sprintf("HEALTH 200 cpu=%.2f mem=%.2f conns=%d qdepth=%d", m.CPU, m.Mem, m.Conns, m.QDepth)
+}
+
+// healthInterval derives the next health-send interval from the weight the
+// balancer last HEALTHACK'd. A weight of zero means no ack has been
+// received yet, so the configured baseRate interval is kept as-is.
+func (c *Client) healthInterval(baseRate time.Duration) time.Duration {
+	weight := atomic.LoadInt32(&c.weight)
+	if weight <= 0 {
+		return baseRate
+	}
+	if weight > 10 {
+		weight = 10
+	}
+	// Low weight (an overloaded client) reports up to 10x more often;
+	// high weight backs off toward the configured base rate.
+	interval := baseRate * time.Duration(weight) / 10
+	if interval < time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+func (c *Client) handleHealthAck(tokens []string) {
+	if len(tokens) < 2 {
+		c.log().Debugf("HEALTHACK received from server with no weight")
+		return
+	}
+	weight, err := strconv.Atoi(tokens[1])
+	if err != nil {
+		c.log().Debugf("HEALTHACK received from server with non-numeric weight: %s", tokens[1])
+		return
+	}
+	atomic.StoreInt32(&c.weight, int32(weight))
+}
+
+// LastHealth returns the most recently reported health metrics, for local
+// observability independent of what's been sent to the balancer.
+func (c *Client) LastHealth() HealthMetrics {
+	if v := c.lastHealth.Load(); v != nil {
+		return v.(HealthMetrics)
+	}
+	return HealthMetrics{}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Start brings the client up: it starts managing the balancer connection
+// (balancer messages and health reports) and begins forwarding packets to
+// the VIP, running until ctx is cancelled, Stop is called, or the balancer
+// deregisters the client. It returns once the background goroutines have
+// been launched, not once they exit.
+func (c *Client) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.done = make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go c.manageBalancerConnection(ctx, &wg)
+	go func() {
+		if err := c.listen(ctx, &wg); err != nil {
+			c.log().Errorf("listen exited: %s", err)
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		c.markDone()
+	}()
+
+	return nil
+}
+
+// Stop runs the same teardown as a balancer-driven shutdown (deregister,
+// close the balancer/data connections, detach the VIP, close the packet
+// sink), cancels the client's context, and blocks until Done is closed.
+func (c *Client) Stop() error {
+	c.gracefulStop()
+	<-c.Done()
+	return nil
+}
+
+// Done returns a channel that is closed once manageBalancerConnection and
+// listen have returned, mirroring context.Context's own Done semantics. The
+// health-sender and packet-handler goroutines exit on the same context
+// cancellation but are not waited for.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Client) markDone() {
+	c.doneOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func (c *Client) setState(s State) {
+	atomic.StoreInt32((*int32)(&c.state), int32(s))
+}
+
+func (c *Client) loadState() State {
+	return State(atomic.LoadInt32((*int32)(&c.state)))
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	logrus "github.com/sirupsen/logrus"
+)
+
+// Field is a structured key/value pair attached to a log line.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger is the logging contract Client writes through, letting callers
+// route the Client's logs into their own pipeline instead of the package
+// logrus singleton. The netstack backend's outbound-pump warnings
+// (netstackSink.pumpOutbound) still go to the logrus singleton. With
+// returns a Logger that attaches fields to every subsequent call.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	With(fields ...Field) Logger
+}
+
+// logrusLogger is the default Logger, preserving the package's historical
+// behaviour of logging through the logrus singleton.
+type logrusLogger struct {
+	entry *logrus.Entry
+}
+
+// NewLogrusLogger adapts the logrus singleton to the Logger interface.
+func NewLogrusLogger() Logger {
+	return &logrusLogger{entry: logrus.NewEntry(logrus.StandardLogger())}
+}
+
+func (l *logrusLogger) Debugf(format string, args ...interface{}) { l.entry.Debugf(format, args...) }
+func (l *logrusLogger) Infof(format string, args ...interface{})  { l.entry.Infof(format, args...) }
+func (l *logrusLogger) Warnf(format string, args ...interface{})  { l.entry.Warnf(format, args...) }
+func (l *logrusLogger) Errorf(format string, args ...interface{}) { l.entry.Errorf(format, args...) }
+
+func (l *logrusLogger) With(fields ...Field) Logger {
+	f := make(logrus.Fields, len(fields))
+	for _, field := range fields {
+		f[field.Key] = field.Value
+	}
+	return &logrusLogger{entry: l.entry.WithFields(f)}
+}
+
+func (c *Client) log() Logger {
+	// log() is called concurrently from every background goroutine Start
+	// launches, so the default-logger lazy init needs its own guard rather
+	// than a bare nil check.
+	c.loggerOnce.Do(func() {
+		if c.logger == nil {
+			c.logger = NewLogrusLogger()
+		}
+	})
+	return c.logger.With(
+		Field{Key: "client", Value: c.name},
+		Field{Key: "vip", Value: c.vip.String()},
+		Field{Key: "state", Value: c.loadState()},
+	)
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"net"
+
+	"github.com/songgao/water"
+	log "github.com/sirupsen/logrus"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv6"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+)
+
+const netstackNICID tcpip.NICID = 1
+
+// netstackSink runs the VIP entirely in userspace on a gVisor netstack, so
+// applications can bind to the VIP without CAP_NET_RAW/root. Inbound packets
+// captured off the wire are injected into the channel.Endpoint by Deliver;
+// anything the stack generates in response (a SYN-ACK, a TCP/UDP payload) is
+// pumped back out through a TUN device so it actually reaches the network
+// instead of dead-ending inside the process.
+type netstackSink struct {
+	stack  *stack.Stack
+	ep     *channel.Endpoint
+	tun    *water.Interface
+	cancel context.CancelFunc
+}
+
+func newNetstackSink() (*netstackSink, error) {
+	s := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol, ipv6.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{tcp.NewProtocol, udp.NewProtocol},
+	})
+
+	ep := channel.New(512, 1500, "")
+	if err := s.CreateNIC(netstackNICID, ep); err != nil {
+		return nil, netstackErr(err)
+	}
+	if err := s.SetSpoofing(netstackNICID, true); err != nil {
+		return nil, netstackErr(err)
+	}
+
+	tun, err := water.New(water.Config{DeviceType: water.TUN})
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sink := &netstackSink{stack: s, ep: ep, tun: tun, cancel: cancel}
+	go sink.pumpOutbound(ctx)
+
+	return sink, nil
+}
+
+// Stack exposes the underlying *stack.Stack so callers can bind to the VIP
+// through gonet.DialTCP/ListenTCP instead of the host socket API.
+func (n *netstackSink) Stack() *stack.Stack {
+	return n.stack
+}
+
+// pumpOutbound writes packets the netstack generates (replies to whatever
+// Deliver injects) out to the TUN device, where the host's own routing table
+// carries them back onto the wire.
+func (n *netstackSink) pumpOutbound(ctx context.Context) {
+	for {
+		pkt := n.ep.ReadContext(ctx)
+		if pkt == nil {
+			return
+		}
+		if _, err := n.tun.Write(pkt.ToView().AsSlice()); err != nil {
+			log.Warnln("Failed to write outbound netstack packet to TUN device: " + err.Error())
+		}
+		pkt.DecRef()
+	}
+}
+
+func (n *netstackSink) AttachVIP(vip net.IP) error {
+	protoNumber := ipv4.ProtocolNumber
+	addr := tcpip.AddrFromSlice(vip.To4())
+	if addr.Len() == 0 {
+		protoNumber = ipv6.ProtocolNumber
+		addr = tcpip.AddrFromSlice(vip.To16())
+	}
+
+	protoAddr := tcpip.ProtocolAddress{
+		Protocol:          protoNumber,
+		AddressWithPrefix: addr.WithPrefix(),
+	}
+	return netstackErr(n.stack.AddProtocolAddress(netstackNICID, protoAddr, stack.AddressProperties{}))
+}
+
+func (n *netstackSink) DetachVIP(vip net.IP) error {
+	addr := tcpip.AddrFromSlice(vip.To4())
+	if addr.Len() == 0 {
+		addr = tcpip.AddrFromSlice(vip.To16())
+	}
+	return netstackErr(n.stack.RemoveAddress(netstackNICID, addr))
+}
+
+func (n *netstackSink) Deliver(packet *rawPacket) error {
+	protoNumber := header.IPv4ProtocolNumber
+	if header.IsV6(packet.payload[:packet.size]) {
+		protoNumber = header.IPv6ProtocolNumber
+	}
+	data := make([]byte, packet.size)
+	copy(data, packet.payload[:packet.size])
+	n.ep.InjectInbound(protoNumber, stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: buffer.MakeWithData(data),
+	}))
+	return nil
+}
+
+func (n *netstackSink) Close() error {
+	n.cancel()
+	n.tun.Close()
+	n.stack.Close()
+	return nil
+}
+
+func netstackErr(err tcpip.Error) error {
+	if err == nil {
+		return nil
+	}
+	return &net.OpError{Op: "netstack", Err: errString(err.String())}
+}
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
@@ -1,16 +1,14 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"net"
-	"os"
 	"strings"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-
 	"github.com/google/gopacket/pcap"
 	"github.com/vishvananda/netlink"
 )
@@ -20,9 +18,13 @@ func findDevice(ip net.IP) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	wantV6 := ip.To4() == nil
 	foundDevice := ""
 	for _, device := range devices {
 		for _, address := range device.Addresses {
+			if (address.IP.To4() == nil) != wantV6 {
+				continue
+			}
 			ipNet := &net.IPNet{IP: address.IP, Mask: address.Netmask}
 			if ipNet.Contains(ip) {
 				if foundDevice == "" {
@@ -50,59 +52,107 @@ func initPacketPool(size int) *sync.Pool {
 	}
 }
 
-func (c *Client) manageBalancerConnection(wg *sync.WaitGroup) {
+func (c *Client) manageBalancerConnection(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
-	go c.sendHealth()
-	defer log.Debugln("ended balancer connection management")
-	for c.state == Active || c.state == Paused {
-		message, err := c.comm.ReadLine()
-		if err != nil {
-			log.Errorln("Read timeout exceeded. Stopping")
-			c.gracefulStop()
-			return
+	go c.sendHealth(ctx)
+	defer c.log().Debugf("ended balancer connection management")
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for {
+			message, err := c.comm.ReadLine()
+			if err != nil {
+				c.log().Errorf("read timeout exceeded, stopping")
+				c.gracefulStop()
+				return
+			}
+			select {
+			case lines <- message:
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
 
-		tokens := strings.Split(message, " ")
-		if len(tokens) < 1 {
-			log.Debugln("Empty message received from server")
-			continue
-		}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-lines:
+			if !ok {
+				return
+			}
 
-		switch tokens[0] {
-		case "INVALID":
-			log.Debugln(message)
+			tokens := strings.Split(message, " ")
+			if len(tokens) < 1 {
+				c.log().Debugf("empty message received from server")
+				continue
+			}
 
-		case "DEREGISTERED":
-			c.state = Deregistering
-			c.gracefulStop()
-			return
+			msgLogger := c.log().With(Field{Key: "msg_tokens", Value: tokens})
+			switch tokens[0] {
+			case "INVALID":
+				msgLogger.Debugf("%s", message)
 
-		case "PAUSED":
-			c.state = Paused
+			case "DEREGISTERED":
+				c.setState(Deregistering)
+				c.gracefulStop()
+				return
 
-		case "RESUMED":
-			c.state = Active
+			case "PAUSED":
+				c.setState(Paused)
 
-		case "HEALTHACK":
-			if len(tokens) < 2 {
-				log.Debugln("HEALTHACK received from server with no status code")
+			case "RESUMED":
+				c.setState(Active)
+
+			case "HEALTHACK":
+				c.handleHealthAck(tokens)
+			default:
+				msgLogger.Debugf("message received from server not matching spec: %s", message)
 			}
-		default:
-			log.Debugln("Message received from server not matching spec: " + message)
 		}
 	}
-
 }
 
-func (c *Client) sendHealth() {
-	for c.state == Active || c.state == Paused {
-		log.Debugln("sending health")
-		c.comm.WriteLine("HEALTH 200")
-		time.Sleep(time.Duration(c.healthRate) * time.Second)
+func (c *Client) sendHealth(ctx context.Context) {
+	if c.healthReporter == nil {
+		c.healthReporter = newProcHealthReporter(c)
+	}
+
+	baseRate := time.Duration(c.healthRate) * time.Second
+	interval := baseRate
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			metrics, err := c.healthReporter.Report()
+			if err != nil {
+				c.log().Warnf("failed to gather health metrics: %s", err)
+				continue
+			}
+			c.lastHealth.Store(metrics)
+
+			c.log().Debugf("sending health")
+			c.comm.WriteLine(formatHealthLine(metrics))
+
+			// A HEALTHACK'd weight scales how often we report: a balancer
+			// that assigns us a low weight (we're overloaded) hears from
+			// us more often so it can react quickly once we recover. Always
+			// rescale from the fixed baseRate, not the loop's mutable
+			// interval, or repeated rescaling compounds toward the floor.
+			if next := c.healthInterval(baseRate); next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
+		}
 	}
 }
 
-func (c *Client) listen(wg *sync.WaitGroup) error {
+func (c *Client) listen(ctx context.Context, wg *sync.WaitGroup) error {
 	defer wg.Done()
 
 	mtu, err := c.getMTU()
@@ -110,24 +160,53 @@ func (c *Client) listen(wg *sync.WaitGroup) error {
 		return err
 	}
 
+	if c.sink == nil {
+		c.sink, err = newPacketSink(c.backend, c.vip)
+		if err != nil {
+			return err
+		}
+	}
+	if err := c.sink.AttachVIP(c.vip); err != nil {
+		return err
+	}
+
+	if c.batchSize > 1 {
+		if raw, ok := c.sink.(*rawSocketSink); ok {
+			return c.listenBatched(ctx, raw)
+		}
+		c.log().Warnf("batch size %d requested but active backend has no raw fd, falling back to single-packet path", c.batchSize)
+	}
+
 	pool := initPacketPool(mtu)
 
 	for i := 0; i < 20; i++ {
-		go c.handlePackets(pool)
+		go c.handlePackets(ctx, pool)
 	}
 
-	c.state = Active
-	for c.state == Active || c.state == Paused {
+	go func() {
+		<-ctx.Done()
+		c.dataListener.Close()
+	}()
+
+	c.setState(Active)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
 		packet := pool.Get().(*rawPacket)
 		n, _, err := c.dataListener.ReadFrom(packet.payload)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
 			return err
 		}
 		packet.size = n
 		c.packets <- packet
 	}
-
-	return nil
 }
 
 func (c *Client) getMTU() (int, error) {
@@ -144,78 +223,69 @@ func (c *Client) getMTU() (int, error) {
 }
 
 func (c *Client) attachVIP() error {
-	lo, err := netlink.LinkByName("lo")
-	if err != nil {
-		return err
-	}
-	vipNet := &net.IPNet{IP: c.vip, Mask: net.CIDRMask(32, 32)}
-	netlink.AddrAdd(lo, &netlink.Addr{IPNet: vipNet})
-	return nil
+	return c.sink.AttachVIP(c.vip)
 }
 
 func (c *Client) detachVIP() error {
-	lo, err := netlink.LinkByName("lo")
-	if err != nil {
-		return err
-	}
-	vipNet := &net.IPNet{IP: c.vip, Mask: net.CIDRMask(32, 32)}
-	netlink.AddrDel(lo, &netlink.Addr{IPNet: vipNet})
-	return nil
+	return c.sink.DetachVIP(c.vip)
 }
 
-func (c *Client) handlePackets(pool *sync.Pool) {
-	fd, _ := syscall.Socket(syscall.AF_INET, syscall.SOCK_RAW, syscall.IPPROTO_RAW)
-
-	if c.vip.To4() == nil {
-		log.Panicln("vip is not ipv4")
-	}
-
-	var vipFour [4]byte
-	copy(vipFour[:], c.vip[:4])
-
-	addr := syscall.SockaddrInet4{
-		Port: 0,
-		Addr: vipFour,
-	}
+func (c *Client) handlePackets(ctx context.Context, pool *sync.Pool) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case packet := <-c.packets:
+			atomic.AddInt64(&c.connCount, 1)
+			if err := c.sink.Deliver(packet); err != nil {
+				c.log().With(Field{Key: "packet_size", Value: packet.size}).Warnf("failed to write packet to local vip")
+			}
 
-	for c.state == Active || c.state == Paused {
-		packet := <-c.packets
-		err := syscall.Sendto(fd, packet.payload[:packet.size], 0, &addr)
-		if err != nil {
-			log.Warnln("Failed to write packet to local vip")
+			pool.Put(packet)
 		}
-
-		pool.Put(packet)
 	}
 }
 
 func (c *Client) deregister() error {
-	c.state = Deregistering
+	c.setState(Deregistering)
 	return c.comm.WriteLine("DEREGISTER " + c.name)
 }
 
+// gracefulStop tears the client down: it's reachable concurrently from the
+// balancer-read-error path, the DEREGISTERED message path, and Stop(), so
+// the actual teardown is guarded by shutdownOnce to make repeat/concurrent
+// calls safe.
 func (c *Client) gracefulStop() {
-	if c.state != Deregistering {
+	c.shutdownOnce.Do(c.teardown)
+}
+
+func (c *Client) teardown() {
+	if c.loadState() != Deregistering {
 		c.deregister()
 	}
 	c.comm.Close()
 	c.dataListener.Close()
 	c.detachVIP()
+	if c.sink != nil {
+		c.sink.Close()
+	}
 	if r := recover(); r != nil {
-		log.Errorln(r)
+		c.log().Errorf("recovered panic during shutdown: %v", r)
+	}
+	if c.cancel != nil {
+		c.cancel()
 	}
-	os.Exit(0)
 }
 
 func (c *Client) pause() error {
-	if c.state == Paused {
+	if c.loadState() == Paused {
 		return errors.New("cannot pause an already paused client")
 	}
 	return c.comm.WriteLine("PAUSE " + c.name)
 }
 
 func (c *Client) resume() error {
-	if c.state == Active {
+	if c.loadState() == Active {
 		return errors.New("cannot resume an already active client")
 	}
 	return c.comm.WriteLine("RESUME " + c.name)
@@ -0,0 +1,36 @@
+package client
+
+import "net"
+
+// Backend selects how a Client delivers inbound packets to the VIP.
+type Backend int
+
+const (
+	// BackendRawSocket installs the VIP on lo and writes packets with a raw
+	// socket. Requires CAP_NET_RAW/root.
+	BackendRawSocket Backend = iota
+	// BackendNetstack runs the VIP entirely in userspace on a gVisor
+	// netstack, so binding to the VIP needs no CAP_NET_RAW. Creating the
+	// backend's TUN device needs CAP_NET_ADMIN.
+	BackendNetstack
+)
+
+// PacketSink is the contract both packet-forwarding backends implement so
+// that handlePackets and listen's single-packet path don't need to know
+// which one is active. listen checks for *rawSocketSink only to decide
+// whether the batched path is available.
+type PacketSink interface {
+	AttachVIP(vip net.IP) error
+	DetachVIP(vip net.IP) error
+	Deliver(packet *rawPacket) error
+	Close() error
+}
+
+func newPacketSink(backend Backend, vip net.IP) (PacketSink, error) {
+	switch backend {
+	case BackendNetstack:
+		return newNetstackSink()
+	default:
+		return newRawSocketSink(vip)
+	}
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+// ethPIPv6 is ETH_P_IPV6 from <linux/if_ether.h>, the protocol value used to
+// steer an AF_PACKET socket to IPv6 payloads.
+const ethPIPv6 = 0x86DD
+
+// rawSocketSink is the original PacketSink implementation: it installs the
+// VIP on lo via netlink and delivers packets with a raw socket.
+//
+// IPv4 delivery uses an AF_INET/IPPROTO_RAW socket, which implies
+// IP_HDRINCL so the already-captured IPv4 header is sent as-is. Linux has
+// no IPV6_HDRINCL equivalent for AF_INET6 raw sockets - the kernel always
+// prepends its own IPv6 header - so IPv6 delivery instead uses an
+// AF_PACKET/SOCK_DGRAM socket addressed to lo's ifindex: in DGRAM mode the kernel builds
+// the link-layer header for us and sends our IPv6 payload (header and all)
+// unmodified.
+type rawSocketSink struct {
+	fd        int
+	vip       net.IP
+	isV6      bool
+	loIfindex int
+}
+
+func newRawSocketSink(vip net.IP) (*rawSocketSink, error) {
+	isV6 := vip.To4() == nil
+
+	if !isV6 {
+		fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_RAW, syscall.IPPROTO_RAW)
+		if err != nil {
+			return nil, err
+		}
+		return &rawSocketSink{fd: fd, vip: vip}, nil
+	}
+
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		return nil, err
+	}
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM, int(htons(ethPIPv6)))
+	if err != nil {
+		return nil, err
+	}
+	return &rawSocketSink{fd: fd, vip: vip, isV6: true, loIfindex: lo.Index}, nil
+}
+
+func htons(v uint16) uint16 {
+	return (v << 8) | (v >> 8)
+}
+
+func vipMask(vip net.IP) net.IPMask {
+	if vip.To4() == nil {
+		return net.CIDRMask(128, 128)
+	}
+	return net.CIDRMask(32, 32)
+}
+
+func (s *rawSocketSink) AttachVIP(vip net.IP) error {
+	lo, err := netlink.LinkByName("lo")
+	if err != nil {
+		return err
+	}
+	vipNet := &net.IPNet{IP: vip, Mask: vipMask(vip)}
+	if err := netlink.AddrAdd(lo, &netlink.Addr{IPNet: vipNet}); err != nil {
+		return err
+	}
+	s.vip = vip
+	return nil
+}
+
+func (s *rawSocketSink) DetachVIP(vip net.IP) error {
+	lo, err := netlink.LinkByName("lo")
+	if err != nil {
+		return err
+	}
+	vipNet := &net.IPNet{IP: vip, Mask: vipMask(vip)}
+	return netlink.AddrDel(lo, &netlink.Addr{IPNet: vipNet})
+}
+
+func (s *rawSocketSink) Deliver(packet *rawPacket) error {
+	if s.isV6 {
+		addr := syscall.SockaddrLinklayer{
+			Protocol: htons(ethPIPv6),
+			Ifindex:  s.loIfindex,
+		}
+		return syscall.Sendto(s.fd, packet.payload[:packet.size], 0, &addr)
+	}
+
+	var vipFour [4]byte
+	copy(vipFour[:], s.vip.To4())
+	addr := syscall.SockaddrInet4{
+		Port: 0,
+		Addr: vipFour,
+	}
+	return syscall.Sendto(s.fd, packet.payload[:packet.size], 0, &addr)
+}
+
+func (s *rawSocketSink) Close() error {
+	return syscall.Close(s.fd)
+}
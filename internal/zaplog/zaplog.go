@@ -0,0 +1,31 @@
+// Package zaplog adapts zap.SugaredLogger to the caplance client.Logger
+// interface, as an alternative to the default logrus adapter.
+package zaplog
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/Pwpon500/caplance/internal/client"
+)
+
+type zapLogger struct {
+	sugar *zap.SugaredLogger
+}
+
+// New adapts a *zap.Logger to client.Logger.
+func New(logger *zap.Logger) client.Logger {
+	return &zapLogger{sugar: logger.Sugar()}
+}
+
+func (l *zapLogger) Debugf(format string, args ...interface{}) { l.sugar.Debugf(format, args...) }
+func (l *zapLogger) Infof(format string, args ...interface{})  { l.sugar.Infof(format, args...) }
+func (l *zapLogger) Warnf(format string, args ...interface{})  { l.sugar.Warnf(format, args...) }
+func (l *zapLogger) Errorf(format string, args ...interface{}) { l.sugar.Errorf(format, args...) }
+
+func (l *zapLogger) With(fields ...client.Field) client.Logger {
+	args := make([]interface{}, 0, len(fields)*2)
+	for _, f := range fields {
+		args = append(args, f.Key, f.Value)
+	}
+	return &zapLogger{sugar: l.sugar.With(args...)}
+}